@@ -0,0 +1,49 @@
+package universal
+
+import corev1 "k8s.io/api/core/v1"
+
+// ConsoleAnnotator derives Console-only display flags for a container from
+// the pod/container pair. The returned map keys become entries in
+// Container.Console; a nil value means "not applicable" and a non-nil
+// pointer carries the derived boolean.
+type ConsoleAnnotator func(pod *Pod, c *corev1.Container) map[string]*bool
+
+var consoleAnnotators = map[string]ConsoleAnnotator{}
+
+// RegisterConsoleAnnotator installs a ConsoleAnnotator under name,
+// overwriting any annotator previously registered under the same name.
+// Downstream users call this from an init() to contribute their own
+// annotation namespace (e.g. "monitoring.mycorp.io/scrape") without
+// forking this package. It is not safe to call concurrently with
+// GetContainers.
+func RegisterConsoleAnnotator(name string, fn ConsoleAnnotator) {
+	consoleAnnotators[name] = fn
+}
+
+func init() {
+	RegisterConsoleAnnotator("caicloud", caicloudConsoleAnnotator)
+}
+
+// caicloudConsoleAnnotator ships the package's original, hardcoded
+// behavior: a container is flagged "isLog" when the pod carries the
+// Caicloud logging annotation.
+func caicloudConsoleAnnotator(pod *Pod, c *corev1.Container) map[string]*bool {
+	return map[string]*bool{
+		"isLog": getConsoleIsLog(pod),
+	}
+}
+
+// runConsoleAnnotators merges the output of every registered
+// ConsoleAnnotator into a single map, suitable for Container.Console.
+func runConsoleAnnotators(pod *Pod, c *corev1.Container) map[string]*bool {
+	ret := map[string]*bool{}
+	for _, annotate := range consoleAnnotators {
+		for k, v := range annotate(pod, c) {
+			ret[k] = v
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
@@ -1,6 +1,10 @@
 package universal
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -19,9 +23,13 @@ type Container struct {
 	Env                []corev1.EnvVar             `json:"env,omitempty"`
 	EnvFrom            []EnvFrom                   `json:"envFrom,omitempty"`
 	Resources          corev1.ResourceRequirements `json:"resources"`
+	ResourceSpec       *ResourceSpec               `json:"resourceSpec,omitempty"`
 	Mounts             []VolumeMount               `json:"mounts,omitempty"`
 	Probe              *ContainerProbe             `json:"probe,omitempty"`
 	Lifecycle          *corev1.Lifecycle           `json:"lifecycle,omitempty"`
+	Role               ContainerRole               `json:"role,omitempty"`
+	StartupOrder       int                         `json:"startupOrder,omitempty"`
+	Console            map[string]*bool            `json:"console,omitempty"`
 	ConsoleIsEnvCustom *bool                       `json:"__isEnvCustom,omitempty"`
 	ConsoleIsEnvFrom   *bool                       `json:"__isEnvFrom,omitempty"`
 	ConsoleIsCommand   *bool                       `json:"__isCommand,omitempty"`
@@ -29,8 +37,22 @@ type Container struct {
 	ConsoleIsLog       *bool                       `json:"__isLog,omitempty"`
 	ConsoleLiveness    *bool                       `json:"__liveness,omitempty"`
 	ConsoleReadiness   *bool                       `json:"__readiness,omitempty"`
+	ConsoleIsInit      *bool                       `json:"__isInit,omitempty"`
+	ConsoleIsEphemeral *bool                       `json:"__isEphemeral,omitempty"`
 }
 
+// ContainerRole classifies a Container by how the kubelet schedules and
+// restarts it, so the console can render init/sidecar/ephemeral containers
+// differently from regular workload containers.
+type ContainerRole string
+
+const (
+	ContainerRoleApp       ContainerRole = "app"
+	ContainerRoleInit      ContainerRole = "init"
+	ContainerRoleSidecar   ContainerRole = "sidecar"
+	ContainerRoleEphemeral ContainerRole = "ephemeral"
+)
+
 type EnvFrom struct {
 	Type string `json:"type"`
 	Name string `json:"name"`
@@ -67,6 +89,65 @@ type Handler struct {
 	Method interface{} `json:"method"`
 }
 
+// UnmarshalJSON decodes Method into the concrete type matching Type,
+// instead of leaving it as the generic map[string]interface{} encoding/json
+// would otherwise produce. Without this, a Handler round-tripped through
+// the console's JSON (read by the console, edited, posted back) would fail
+// every type assertion in toK8sHandler and could panic comparisons that
+// assume Method is a comparable pointer type.
+func (h *Handler) UnmarshalJSON(data []byte) error {
+	var alias struct {
+		Type   string          `json:"type"`
+		Method json.RawMessage `json:"method"`
+	}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	h.Type = alias.Type
+	if len(alias.Method) == 0 || string(alias.Method) == "null" {
+		h.Method = nil
+		return nil
+	}
+
+	switch alias.Type {
+	case "EXEC":
+		m := &corev1.ExecAction{}
+		if err := json.Unmarshal(alias.Method, m); err != nil {
+			return err
+		}
+		h.Method = m
+	case "HTTP":
+		m := &HTTPGetAction{}
+		if err := json.Unmarshal(alias.Method, m); err != nil {
+			return err
+		}
+		h.Method = m
+	case "TCP":
+		m := &corev1.TCPSocketAction{}
+		if err := json.Unmarshal(alias.Method, m); err != nil {
+			return err
+		}
+		h.Method = m
+	case "GRPC":
+		m := &GRPCAction{}
+		if err := json.Unmarshal(alias.Method, m); err != nil {
+			return err
+		}
+		h.Method = m
+	case "NAMED_PIPE":
+		m := &NamedPipeAction{}
+		if err := json.Unmarshal(alias.Method, m); err != nil {
+			return err
+		}
+		h.Method = m
+	default:
+		// Unknown type: keep the raw JSON as a string rather than the
+		// decoded map[string]interface{}, so Method stays comparable.
+		h.Method = string(alias.Method)
+	}
+	return nil
+}
+
 type HTTPGetAction struct {
 	Path        string              `json:"path,omitempty"`
 	Port        intstr.IntOrString  `json:"port"`
@@ -75,39 +156,102 @@ type HTTPGetAction struct {
 	HTTPHeaders []corev1.HTTPHeader `json:"headers,omitempty"`
 }
 
+type GRPCAction struct {
+	Port    int32   `json:"port"`
+	Service *string `json:"service,omitempty"`
+}
+
+// NamedPipeAction is a synthetic handler type with no corev1 equivalent,
+// used to probe Windows workloads that signal health over a named pipe
+// (e.g. `\\.\pipe\health`) rather than a TCP port. There being no kubelet
+// support for this, it is carried over the wire as an Exec probe whose
+// single command argument is the pipe path; namedPipePrefix is how
+// convertHandler/toK8sHandler recognize and round-trip that convention.
+type NamedPipeAction struct {
+	Path string `json:"path"`
+}
+
+const namedPipePrefix = `\\.\pipe\`
+
+func isNamedPipeExec(exec *corev1.ExecAction) bool {
+	return len(exec.Command) == 1 && strings.HasPrefix(exec.Command[0], namedPipePrefix)
+}
+
 func GetContainers(pod *Pod, containers []corev1.Container, volumes []*Volume) []*Container {
 	ret := make([]*Container, 0, len(containers))
-	for _, c := range containers {
-		vmounts := convertVolumeMounts(c.VolumeMounts, volumes)
-		con := &Container{
-			Name:               c.Name,
-			Image:              c.Image,
-			ImagePullPolicy:    c.ImagePullPolicy,
-			TTY:                c.TTY,
-			Command:            c.Command,
-			Args:               c.Args,
-			WorkingDir:         c.WorkingDir,
-			SecurityContext:    c.SecurityContext,
-			Ports:              c.Ports,
-			EnvFrom:            convertEnvFrom(c.EnvFrom),
-			Env:                c.Env,
-			Resources:          c.Resources,
-			Mounts:             vmounts,
-			Probe:              convertContainerProbe(c.LivenessProbe, c.ReadinessProbe),
-			Lifecycle:          c.Lifecycle,
-			ConsoleIsEnvCustom: getConsoleIsEnvCustom(&c),
-			ConsoleIsEnvFrom:   getConsoleIsEnvFrom(&c),
-			ConsoleIsCommand:   getConsoleIsCommand(&c),
-			ConsoleIsMountFile: getConsoleIsMountFile(vmounts),
-			ConsoleIsLog:       getConsoleIsLog(pod),
-			ConsoleLiveness:    getConsoleLiveness(&c),
-			ConsoleReadiness:   getConsoleReadiness(&c),
-		}
-		ret = append(ret, con)
+	for i, c := range containers {
+		ret = append(ret, convertContainer(pod, &c, volumes, ContainerRoleApp, i))
+	}
+	return ret
+}
+
+// GetInitContainers converts corev1.Pod.Spec.InitContainers into
+// universal Containers, populating Pod.InitContainers. Native sidecars
+// (init containers with RestartPolicy: Always, kubernetes 1.28+) are
+// tagged with ContainerRoleSidecar instead of ContainerRoleInit so the
+// console can render them alongside the regular containers they run
+// beside rather than as one-shot init steps.
+func GetInitContainers(pod *Pod, initContainers []corev1.Container, volumes []*Volume) []*Container {
+	ret := make([]*Container, 0, len(initContainers))
+	for i, c := range initContainers {
+		role := ContainerRoleInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			role = ContainerRoleSidecar
+		}
+		ret = append(ret, convertContainer(pod, &c, volumes, role, i))
 	}
 	return ret
 }
 
+// GetEphemeralContainers converts corev1.Pod.Spec.EphemeralContainers into
+// universal Containers, populating Pod.EphemeralContainers. These model
+// debug containers injected into a running pod (e.g. via `kubectl debug`
+// or a `podman container clone`-style workflow). The apiserver rejects
+// probes, lifecycle hooks, and resources on them; those fields are copied
+// as given, so they are empty for any ephemeral container it accepted.
+func GetEphemeralContainers(pod *Pod, ephemeralContainers []corev1.EphemeralContainer, volumes []*Volume) []*Container {
+	ret := make([]*Container, 0, len(ephemeralContainers))
+	for i, ec := range ephemeralContainers {
+		c := corev1.Container(ec.EphemeralContainerCommon)
+		ret = append(ret, convertContainer(pod, &c, volumes, ContainerRoleEphemeral, i))
+	}
+	return ret
+}
+
+func convertContainer(pod *Pod, c *corev1.Container, volumes []*Volume, role ContainerRole, order int) *Container {
+	vmounts := convertVolumeMounts(c.VolumeMounts, volumes)
+	return &Container{
+		Name:               c.Name,
+		Image:              c.Image,
+		ImagePullPolicy:    c.ImagePullPolicy,
+		TTY:                c.TTY,
+		Command:            c.Command,
+		Args:               c.Args,
+		WorkingDir:         c.WorkingDir,
+		SecurityContext:    c.SecurityContext,
+		Ports:              c.Ports,
+		EnvFrom:            convertEnvFrom(c.EnvFrom),
+		Env:                c.Env,
+		Resources:          c.Resources,
+		ResourceSpec:       GetContainerResources(c),
+		Mounts:             vmounts,
+		Probe:              convertContainerProbe(c.LivenessProbe, c.ReadinessProbe),
+		Lifecycle:          c.Lifecycle,
+		Role:               role,
+		StartupOrder:       order,
+		Console:            runConsoleAnnotators(pod, c),
+		ConsoleIsEnvCustom: getConsoleIsEnvCustom(c),
+		ConsoleIsEnvFrom:   getConsoleIsEnvFrom(c),
+		ConsoleIsCommand:   getConsoleIsCommand(c),
+		ConsoleIsMountFile: getConsoleIsMountFile(vmounts),
+		ConsoleIsLog:       getConsoleIsLog(pod),
+		ConsoleLiveness:    getConsoleLiveness(c),
+		ConsoleReadiness:   getConsoleReadiness(c),
+		ConsoleIsInit:      convertBoolToPointer(role == ContainerRoleInit || role == ContainerRoleSidecar),
+		ConsoleIsEphemeral: convertBoolToPointer(role == ContainerRoleEphemeral),
+	}
+}
+
 // =================================================================================================
 
 func convertVolumeMounts(vmounts []corev1.VolumeMount, volumes []*Volume) []VolumeMount {
@@ -162,7 +306,7 @@ func convertContainerProbe(liveness, readiness *corev1.Probe) *ContainerProbe {
 
 func convertProbe(probe *corev1.Probe) *Probe {
 	return &Probe{
-		Handler:             convertHandler(probe.Handler),
+		Handler:             convertHandler(probe.ProbeHandler),
 		InitialDelaySeconds: probe.InitialDelaySeconds,
 		TimeoutSeconds:      probe.TimeoutSeconds,
 		PeriodSeconds:       probe.PeriodSeconds,
@@ -173,9 +317,12 @@ func convertProbe(probe *corev1.Probe) *Probe {
 	}
 }
 
-func convertHandler(handler corev1.Handler) Handler {
+func convertHandler(handler corev1.ProbeHandler) Handler {
 	ret := Handler{}
 	switch {
+	case handler.Exec != nil && isNamedPipeExec(handler.Exec):
+		ret.Type = "NAMED_PIPE"
+		ret.Method = &NamedPipeAction{Path: handler.Exec.Command[0]}
 	case handler.Exec != nil:
 		ret.Type = "EXEC"
 		ret.Method = handler.Exec
@@ -191,6 +338,12 @@ func convertHandler(handler corev1.Handler) Handler {
 	case handler.TCPSocket != nil:
 		ret.Type = "TCP"
 		ret.Method = handler.TCPSocket
+	case handler.GRPC != nil:
+		ret.Type = "GRPC"
+		ret.Method = &GRPCAction{
+			Port:    handler.GRPC.Port,
+			Service: handler.GRPC.Service,
+		}
 	default:
 		glog.Errorf("unsuport handler: %s", handler)
 	}
@@ -200,6 +353,80 @@ func convertHandler(handler corev1.Handler) Handler {
 
 // =================================================================================================
 
+// toK8sProbe converts a universal Probe back into a corev1.Probe so it can
+// be pushed back into a PodSpec after being edited by the console.
+func toK8sProbe(probe *Probe) (*corev1.Probe, error) {
+	if probe == nil {
+		return nil, nil
+	}
+	handler, err := toK8sHandler(probe.Handler)
+	if err != nil {
+		return nil, err
+	}
+	ret := &corev1.Probe{
+		ProbeHandler:        handler,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+	}
+	if probe.Threshold != nil {
+		ret.SuccessThreshold = probe.Threshold.SuccessThreshold
+		ret.FailureThreshold = probe.Threshold.FailureThreshold
+	}
+	return ret, nil
+}
+
+// toK8sHandler converts a universal Handler back into a corev1.ProbeHandler.
+// "NAMED_PIPE" has no corev1 representation and is carried as an Exec
+// probe per the namedPipePrefix convention (see NamedPipeAction).
+func toK8sHandler(handler Handler) (corev1.ProbeHandler, error) {
+	switch handler.Type {
+	case "EXEC":
+		exec, ok := handler.Method.(*corev1.ExecAction)
+		if !ok {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type EXEC: unexpected method %T", handler.Method)
+		}
+		return corev1.ProbeHandler{Exec: exec}, nil
+	case "HTTP":
+		http, ok := handler.Method.(*HTTPGetAction)
+		if !ok {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type HTTP: unexpected method %T", handler.Method)
+		}
+		return corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+			Path:        http.Path,
+			Port:        http.Port,
+			Host:        http.Host,
+			Scheme:      http.Scheme,
+			HTTPHeaders: http.HTTPHeaders,
+		}}, nil
+	case "TCP":
+		tcp, ok := handler.Method.(*corev1.TCPSocketAction)
+		if !ok {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type TCP: unexpected method %T", handler.Method)
+		}
+		return corev1.ProbeHandler{TCPSocket: tcp}, nil
+	case "GRPC":
+		grpc, ok := handler.Method.(*GRPCAction)
+		if !ok {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type GRPC: unexpected method %T", handler.Method)
+		}
+		return corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: grpc.Port, Service: grpc.Service}}, nil
+	case "NAMED_PIPE":
+		pipe, ok := handler.Method.(*NamedPipeAction)
+		if !ok {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type NAMED_PIPE: unexpected method %T", handler.Method)
+		}
+		if !strings.HasPrefix(pipe.Path, namedPipePrefix) {
+			return corev1.ProbeHandler{}, fmt.Errorf("handler type NAMED_PIPE: path %q lacks prefix %q", pipe.Path, namedPipePrefix)
+		}
+		return corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{pipe.Path}}}, nil
+	default:
+		return corev1.ProbeHandler{}, fmt.Errorf("unsupported handler type: %s", handler.Type)
+	}
+}
+
+// =================================================================================================
+
 func getConsoleIsEnvCustom(c *corev1.Container) *bool {
 	return convertBoolToPointer(c.Env != nil && len(c.Env) != 0)
 }
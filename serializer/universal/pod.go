@@ -0,0 +1,44 @@
+package universal
+
+import corev1 "k8s.io/api/core/v1"
+
+// Pod is the universal view of a corev1.Pod consumed by the console.
+type Pod struct {
+	Name                string       `json:"name"`
+	Namespace           string       `json:"namespace"`
+	Annotations         []Annotation `json:"annotations,omitempty"`
+	Containers          []*Container `json:"containers,omitempty"`
+	InitContainers      []*Container `json:"initContainers,omitempty"`
+	EphemeralContainers []*Container `json:"ephemeralContainers,omitempty"`
+}
+
+type Annotation struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// GetPod converts a corev1.Pod into a universal Pod, populating
+// Containers, InitContainers, and EphemeralContainers from the
+// corresponding PodSpec slices.
+func GetPod(p *corev1.Pod, volumes []*Volume) *Pod {
+	pod := &Pod{
+		Name:        p.Name,
+		Namespace:   p.Namespace,
+		Annotations: convertAnnotations(p.Annotations),
+	}
+	pod.Containers = GetContainers(pod, p.Spec.Containers, volumes)
+	pod.InitContainers = GetInitContainers(pod, p.Spec.InitContainers, volumes)
+	pod.EphemeralContainers = GetEphemeralContainers(pod, p.Spec.EphemeralContainers, volumes)
+	return pod
+}
+
+func convertAnnotations(annotations map[string]string) []Annotation {
+	if len(annotations) == 0 {
+		return nil
+	}
+	ret := make([]Annotation, 0, len(annotations))
+	for k, v := range annotations {
+		ret = append(ret, Annotation{Key: k, Value: v})
+	}
+	return ret
+}
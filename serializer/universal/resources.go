@@ -0,0 +1,220 @@
+package universal
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ResourceSpec is a normalized, typed view of a corev1.ResourceRequirements
+// so the console doesn't have to parse resource.Quantity strings itself.
+type ResourceSpec struct {
+	// CPU is in millicores (e.g. 500 == "500m" == half a core).
+	CPU *ResourceQuantity `json:"cpu,omitempty"`
+	// Memory and EphemeralStorage are in bytes.
+	Memory           *ResourceQuantity           `json:"memory,omitempty"`
+	EphemeralStorage *ResourceQuantity           `json:"ephemeralStorage,omitempty"`
+	Extended         map[string]ExtendedResource `json:"extended,omitempty"`
+}
+
+// ResourceQuantity carries the request and limit for a single resource,
+// both already converted to the unit documented on the field that embeds it.
+type ResourceQuantity struct {
+	Request int64 `json:"request,omitempty"`
+	Limit   int64 `json:"limit,omitempty"`
+}
+
+// ExtendedResource is any resource name outside the built-in cpu/memory/
+// ephemeral-storage triad: GPUs, HugePages, and arbitrary vendor device
+// plugins. Request/Limit are left as the raw quantity value (most extended
+// resources, like nvidia.com/gpu, are always integral anyway).
+type ExtendedResource struct {
+	Request int64 `json:"request,omitempty"`
+	Limit   int64 `json:"limit,omitempty"`
+}
+
+const (
+	resourceNameNvidiaGPU = "nvidia.com/gpu"
+	resourceNameAMDGPU    = "amd.com/gpu"
+)
+
+// GetContainerResources normalizes a container's resource requests/limits
+// into a ResourceSpec. It returns nil when the container declares no
+// requests or limits at all.
+func GetContainerResources(c *corev1.Container) *ResourceSpec {
+	if len(c.Resources.Requests) == 0 && len(c.Resources.Limits) == 0 {
+		return nil
+	}
+
+	spec := &ResourceSpec{
+		CPU:              cpuQuantity(c.Resources),
+		Memory:           byteQuantity(c.Resources, corev1.ResourceMemory),
+		EphemeralStorage: byteQuantity(c.Resources, corev1.ResourceEphemeralStorage),
+		Extended:         map[string]ExtendedResource{},
+	}
+
+	for name := range c.Resources.Requests {
+		if isBuiltinResource(name) {
+			continue
+		}
+		spec.Extended[string(name)] = extendedResource(c.Resources, name)
+	}
+	for name := range c.Resources.Limits {
+		if isBuiltinResource(name) {
+			continue
+		}
+		if _, ok := spec.Extended[string(name)]; !ok {
+			spec.Extended[string(name)] = extendedResource(c.Resources, name)
+		}
+	}
+	if len(spec.Extended) == 0 {
+		spec.Extended = nil
+	}
+
+	return spec
+}
+
+func isBuiltinResource(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return true
+	default:
+		return false
+	}
+}
+
+func cpuQuantity(r corev1.ResourceRequirements) *ResourceQuantity {
+	req, hasReq := r.Requests[corev1.ResourceCPU]
+	lim, hasLim := r.Limits[corev1.ResourceCPU]
+	if !hasReq && !hasLim {
+		return nil
+	}
+	ret := &ResourceQuantity{}
+	if hasReq {
+		ret.Request = req.MilliValue()
+	}
+	if hasLim {
+		ret.Limit = lim.MilliValue()
+	}
+	return ret
+}
+
+func byteQuantity(r corev1.ResourceRequirements, name corev1.ResourceName) *ResourceQuantity {
+	req, hasReq := r.Requests[name]
+	lim, hasLim := r.Limits[name]
+	if !hasReq && !hasLim {
+		return nil
+	}
+	ret := &ResourceQuantity{}
+	if hasReq {
+		ret.Request = req.Value()
+	}
+	if hasLim {
+		ret.Limit = lim.Value()
+	}
+	return ret
+}
+
+func extendedResource(r corev1.ResourceRequirements, name corev1.ResourceName) ExtendedResource {
+	ret := ExtendedResource{}
+	if req, ok := r.Requests[name]; ok {
+		ret.Request = req.Value()
+	}
+	if lim, ok := r.Limits[name]; ok {
+		ret.Limit = lim.Value()
+	}
+	return ret
+}
+
+// ValidateContainerResources enforces requests <= limits per resource and
+// warns (via the returned warnings slice, not an error) when a GPU request
+// is set without a matching limit, since the Kubernetes scheduler silently
+// treats an unset GPU limit as "no GPU" rather than "unbounded".
+func ValidateContainerResources(spec *ResourceSpec) (warnings []string, err error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	checkQuantity := func(name string, q *ResourceQuantity) error {
+		if q == nil {
+			return nil
+		}
+		if q.Request > 0 && q.Limit > 0 && q.Request > q.Limit {
+			return fmt.Errorf("%s: request (%d) exceeds limit (%d)", name, q.Request, q.Limit)
+		}
+		return nil
+	}
+
+	if err := checkQuantity("cpu", spec.CPU); err != nil {
+		return nil, err
+	}
+	if err := checkQuantity("memory", spec.Memory); err != nil {
+		return nil, err
+	}
+	if err := checkQuantity("ephemeral-storage", spec.EphemeralStorage); err != nil {
+		return nil, err
+	}
+
+	for name, ext := range spec.Extended {
+		if ext.Request > 0 && ext.Limit > 0 && ext.Request > ext.Limit {
+			return nil, fmt.Errorf("%s: request (%d) exceeds limit (%d)", name, ext.Request, ext.Limit)
+		}
+		if isGPUResource(name) && ext.Request > 0 && ext.Limit == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: request is set without a matching limit; the scheduler will not reserve a GPU for this container", name))
+		}
+	}
+
+	return warnings, nil
+}
+
+func isGPUResource(name string) bool {
+	return name == resourceNameNvidiaGPU || name == resourceNameAMDGPU || strings.HasSuffix(name, ".com/gpu")
+}
+
+// toK8sResources converts an edited ResourceSpec back into a
+// corev1.ResourceRequirements, the inverse of GetContainerResources. A nil
+// spec converts to an empty ResourceRequirements.
+func toK8sResources(spec *ResourceSpec) corev1.ResourceRequirements {
+	ret := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	if spec == nil {
+		return ret
+	}
+
+	setQuantity(ret, corev1.ResourceCPU, spec.CPU, func(v int64) resource.Quantity {
+		return *resource.NewMilliQuantity(v, resource.DecimalSI)
+	})
+	setQuantity(ret, corev1.ResourceMemory, spec.Memory, func(v int64) resource.Quantity {
+		return *resource.NewQuantity(v, resource.BinarySI)
+	})
+	setQuantity(ret, corev1.ResourceEphemeralStorage, spec.EphemeralStorage, func(v int64) resource.Quantity {
+		return *resource.NewQuantity(v, resource.BinarySI)
+	})
+	for name, ext := range spec.Extended {
+		rn := corev1.ResourceName(name)
+		setQuantity(ret, rn, &ResourceQuantity{Request: ext.Request, Limit: ext.Limit}, func(v int64) resource.Quantity {
+			return *resource.NewQuantity(v, resource.DecimalSI)
+		})
+	}
+
+	if len(ret.Requests) == 0 {
+		ret.Requests = nil
+	}
+	if len(ret.Limits) == 0 {
+		ret.Limits = nil
+	}
+	return ret
+}
+
+func setQuantity(ret corev1.ResourceRequirements, name corev1.ResourceName, q *ResourceQuantity, toQuantity func(int64) resource.Quantity) {
+	if q == nil {
+		return
+	}
+	if q.Request > 0 {
+		ret.Requests[name] = toQuantity(q.Request)
+	}
+	if q.Limit > 0 {
+		ret.Limits[name] = toQuantity(q.Limit)
+	}
+}
@@ -0,0 +1,210 @@
+package universal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ToK8sContainers converts universal Containers of every Role back into
+// the three PodSpec slices they came from, ordering each group by
+// StartupOrder. Console-only fields (everything prefixed "__", plus
+// Role/StartupOrder/Console) have no corev1 equivalent and are dropped.
+// ResourceSpec is the exception: when set, it is converted back via
+// toK8sResources and takes precedence over the raw Resources field, since
+// it is what the console actually edits.
+//
+// Containers with ContainerRoleApp go into PodSpec.Containers.
+// ContainerRoleInit and ContainerRoleSidecar both go into
+// PodSpec.InitContainers (a native sidecar is an init container with
+// RestartPolicy: Always; toK8sContainer sets that from Role).
+// ContainerRoleEphemeral containers cannot be represented as
+// corev1.Container at all and are returned separately as
+// corev1.EphemeralContainer.
+func ToK8sContainers(cs []*Container, volumes []*Volume) (containers []corev1.Container, initContainers []corev1.Container, ephemeralContainers []corev1.EphemeralContainer, err error) {
+	byRole := map[ContainerRole][]*Container{}
+	for _, c := range cs {
+		switch c.Role {
+		case ContainerRoleApp, ContainerRoleInit, ContainerRoleSidecar, ContainerRoleEphemeral:
+		default:
+			return nil, nil, nil, fmt.Errorf("container %q: unknown role %q", c.Name, c.Role)
+		}
+		byRole[c.Role] = append(byRole[c.Role], c)
+	}
+	for _, group := range byRole {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].StartupOrder < group[j].StartupOrder })
+	}
+
+	for _, c := range byRole[ContainerRoleApp] {
+		kc, err := toK8sContainer(c, volumes)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("container %q: %v", c.Name, err)
+		}
+		containers = append(containers, *kc)
+	}
+	initGroup := append(append([]*Container{}, byRole[ContainerRoleInit]...), byRole[ContainerRoleSidecar]...)
+	sort.SliceStable(initGroup, func(i, j int) bool { return initGroup[i].StartupOrder < initGroup[j].StartupOrder })
+	for _, c := range initGroup {
+		kc, err := toK8sContainer(c, volumes)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("init container %q: %v", c.Name, err)
+		}
+		initContainers = append(initContainers, *kc)
+	}
+	for _, c := range byRole[ContainerRoleEphemeral] {
+		ec, err := toK8sEphemeralContainer(c, volumes)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("ephemeral container %q: %v", c.Name, err)
+		}
+		ephemeralContainers = append(ephemeralContainers, *ec)
+	}
+
+	return containers, initContainers, ephemeralContainers, nil
+}
+
+// toK8sEphemeralContainer converts a ContainerRoleEphemeral Container into
+// a corev1.EphemeralContainer. TargetContainerName is left empty (debug
+// the pod's network/IPC/PID namespaces rather than a specific container),
+// since universal.Container has no field to carry it.
+func toK8sEphemeralContainer(c *Container, volumes []*Volume) (*corev1.EphemeralContainer, error) {
+	kc, err := toK8sContainer(c, volumes)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon(*kc),
+	}, nil
+}
+
+func toK8sContainer(c *Container, volumes []*Volume) (*corev1.Container, error) {
+	vmounts, err := toK8sVolumeMounts(c.Mounts, volumes)
+	if err != nil {
+		return nil, err
+	}
+	envFrom, err := toK8sEnvFrom(c.EnvFrom)
+	if err != nil {
+		return nil, err
+	}
+	liveness, readiness, err := toK8sContainerProbe(c.Probe)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources come from the edited ResourceSpec when the console set one;
+	// otherwise fall back to the raw, untouched corev1.ResourceRequirements.
+	resourceSpec := c.ResourceSpec
+	resources := c.Resources
+	if resourceSpec != nil {
+		resources = toK8sResources(resourceSpec)
+	} else {
+		resourceSpec = GetContainerResources(&corev1.Container{Resources: resources})
+	}
+	warnings, err := ValidateContainerResources(resourceSpec)
+	if err != nil {
+		return nil, fmt.Errorf("resources: %v", err)
+	}
+	for _, w := range warnings {
+		glog.Warningf("container %s: %s", c.Name, w)
+	}
+
+	ret := &corev1.Container{
+		Name:            c.Name,
+		Image:           c.Image,
+		ImagePullPolicy: c.ImagePullPolicy,
+		TTY:             c.TTY,
+		Command:         c.Command,
+		Args:            c.Args,
+		WorkingDir:      c.WorkingDir,
+		SecurityContext: c.SecurityContext,
+		Ports:           c.Ports,
+		Env:             c.Env,
+		EnvFrom:         envFrom,
+		Resources:       resources,
+		VolumeMounts:    vmounts,
+		LivenessProbe:   liveness,
+		ReadinessProbe:  readiness,
+		Lifecycle:       c.Lifecycle,
+	}
+	if c.Role == ContainerRoleSidecar {
+		always := corev1.ContainerRestartPolicyAlways
+		ret.RestartPolicy = &always
+	}
+
+	return ret, nil
+}
+
+// toK8sVolumeMounts converts universal VolumeMounts back into
+// corev1.VolumeMounts, erroring if a mount refers to a volume that isn't
+// in volumes (it would otherwise serialize a PodSpec the apiserver rejects).
+func toK8sVolumeMounts(mounts []VolumeMount, volumes []*Volume) ([]corev1.VolumeMount, error) {
+	if len(mounts) == 0 {
+		return nil, nil
+	}
+	vset := make(map[string]struct{}, len(volumes))
+	for _, v := range volumes {
+		vset[v.Name] = struct{}{}
+	}
+	ret := make([]corev1.VolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		if _, ok := vset[m.Name]; !ok {
+			return nil, fmt.Errorf("mount %q refers to unknown volume %q", m.MountPath, m.Name)
+		}
+		ret = append(ret, corev1.VolumeMount{
+			Name:      m.Name,
+			ReadOnly:  m.ReadOnly,
+			MountPath: m.MountPath,
+			SubPath:   m.SubPath,
+		})
+	}
+	return ret, nil
+}
+
+// toK8sEnvFrom converts universal EnvFrom entries back into
+// corev1.EnvFromSource, erroring on an EnvFrom.Type the console shouldn't
+// be able to produce.
+func toK8sEnvFrom(list []EnvFrom) ([]corev1.EnvFromSource, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	ret := make([]corev1.EnvFromSource, 0, len(list))
+	for _, ef := range list {
+		switch ef.Type {
+		case "Config":
+			ret = append(ret, corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ef.Name},
+			}})
+		case "Secret":
+			ret = append(ret, corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ef.Name},
+			}})
+		default:
+			return nil, fmt.Errorf("unknown envFrom type: %s", ef.Type)
+		}
+	}
+	return ret, nil
+}
+
+// toK8sContainerProbe converts a universal ContainerProbe back into the
+// corev1 liveness/readiness probe pair. It rejects a ContainerProbe whose
+// Liveness and Readiness share the same Handler.Method pointer: the
+// console should always produce independent handler instances, and a
+// shared pointer would let an edit to one probe silently mutate the other.
+func toK8sContainerProbe(cp *ContainerProbe) (liveness, readiness *corev1.Probe, err error) {
+	if cp == nil {
+		return nil, nil, nil
+	}
+	if cp.Liveness != nil && cp.Readiness != nil &&
+		cp.Liveness.Handler.Method != nil &&
+		cp.Liveness.Handler.Method == cp.Readiness.Handler.Method {
+		return nil, nil, fmt.Errorf("liveness and readiness probes must not alias the same handler method")
+	}
+	if liveness, err = toK8sProbe(cp.Liveness); err != nil {
+		return nil, nil, fmt.Errorf("liveness probe: %v", err)
+	}
+	if readiness, err = toK8sProbe(cp.Readiness); err != nil {
+		return nil, nil, fmt.Errorf("readiness probe: %v", err)
+	}
+	return liveness, readiness, nil
+}